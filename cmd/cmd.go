@@ -0,0 +1,113 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Typed IEC 62386-102 application command helpers. Callers
+ *    					 used to hand-encode every command, including the
+ *	 						 DTR-based two-frame sequences scenes, groups, fade
+ *							 timing and memory bank reads need. Each helper here
+ * 							 returns the forward frame(s) a command takes to build,
+ *							 already in send order; the transport/Driver layer is
+ *							 responsible for the inter-frame gap (§11.2: a "send twice"
+ *							 config command needs >= 9.17 ms between its two frames).
+ *
+ */
+
+package cmd
+
+import "github.com/ThomasGeor/dali/daliframe"
+
+// special commands (IEC 62386-102 §11.3) used to stage DTR0/DTR1 before a
+// command that reads from them.
+const (
+	dtr0Addr uint8 = 0b10100011
+	dtr1Addr uint8 = 0b11000011
+)
+
+// addressed commands (IEC 62386-102 §11.2).
+const (
+	goToSceneBase         uint8 = 0x10 // + scene number 0-15
+	storeDTRAsFadeTimeCmd uint8 = 0x2E
+	storeDTRAsFadeRateCmd uint8 = 0x2F
+	storeDTRAsSceneBase   uint8 = 0x40 // + scene number 0-15
+	removeFromSceneBase   uint8 = 0x50 // + scene number 0-15
+	addToGroupBase        uint8 = 0x60 // + group number 0-15
+	removeFromGroupBase   uint8 = 0x70 // + group number 0-15
+	queryDeviceTypeCmd    uint8 = 0x99
+	readMemoryLocationCmd uint8 = 0xE0
+)
+
+// sendTwice pairs frame with itself: config commands (the 0x20-0x7F
+// range among others) only take effect once the gear sees the exact same
+// forward frame twice within the timing profile's "send twice" window.
+func sendTwice(frame daliframe.ForwardFrame) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{frame, frame}
+}
+
+// DAPC (Direct Arc Power Control) sets address's output directly to
+// level (0-254; 255 is MASK and is ignored by the receiving gear).
+func DAPC(address, level uint8) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{{Address: address, Command: level}}
+}
+
+// GoToScene recalls scene (0-15) on address.
+func GoToScene(address, scene uint8) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{{Address: address, Command: goToSceneBase + scene}}
+}
+
+// SetScene stores level into address's scene (0-15): DTR0 takes the
+// level, then STORE DTR AS SCENE x is sent twice.
+func SetScene(address, scene, level uint8) []daliframe.ForwardFrame {
+	frames := []daliframe.ForwardFrame{{Address: dtr0Addr, Command: level}}
+	return append(frames, sendTwice(daliframe.ForwardFrame{Address: address, Command: storeDTRAsSceneBase + scene})...)
+}
+
+// RemoveFromScene removes address from scene (0-15).
+func RemoveFromScene(address, scene uint8) []daliframe.ForwardFrame {
+	return sendTwice(daliframe.ForwardFrame{Address: address, Command: removeFromSceneBase + scene})
+}
+
+// AddToGroup adds address to group (0-15).
+func AddToGroup(address, group uint8) []daliframe.ForwardFrame {
+	return sendTwice(daliframe.ForwardFrame{Address: address, Command: addToGroupBase + group})
+}
+
+// RemoveFromGroup removes address from group (0-15).
+func RemoveFromGroup(address, group uint8) []daliframe.ForwardFrame {
+	return sendTwice(daliframe.ForwardFrame{Address: address, Command: removeFromGroupBase + group})
+}
+
+// SetFadeTime sets address's fade time to the IEC 62386-102 table 9
+// encoded value fadeTime (0-15, fade time = 0.5 * 2^(fadeTime/2) seconds).
+func SetFadeTime(address, fadeTime uint8) []daliframe.ForwardFrame {
+	frames := []daliframe.ForwardFrame{{Address: dtr0Addr, Command: fadeTime}}
+	return append(frames, sendTwice(daliframe.ForwardFrame{Address: address, Command: storeDTRAsFadeTimeCmd})...)
+}
+
+// SetFadeRate sets address's fade rate to the IEC 62386-102 table 9
+// encoded value fadeRate (1-15 steps/second = 506/2^(fadeRate/2)).
+func SetFadeRate(address, fadeRate uint8) []daliframe.ForwardFrame {
+	frames := []daliframe.ForwardFrame{{Address: dtr0Addr, Command: fadeRate}}
+	return append(frames, sendTwice(daliframe.ForwardFrame{Address: address, Command: storeDTRAsFadeRateCmd})...)
+}
+
+// QueryDeviceType asks address which IEC 62386-2xx device type(s) it
+// implements; the caller reads the backward frame off the transport.
+func QueryDeviceType(address uint8) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{{Address: address, Command: queryDeviceTypeCmd}}
+}
+
+// ReadMemoryBank sequences the DTR1 (bank)/DTR0 (offset) writes and then
+// one READ MEMORY LOCATION per byte length requires; the gear
+// auto-increments DTR0 after each read, so each frame returns the next
+// byte of the bank starting at offset.
+func ReadMemoryBank(address, bank, offset, length uint8) []daliframe.ForwardFrame {
+	frames := []daliframe.ForwardFrame{
+		{Address: dtr1Addr, Command: bank},
+		{Address: dtr0Addr, Command: offset},
+	}
+	for i := uint8(0); i < length; i++ {
+		frames = append(frames, daliframe.ForwardFrame{Address: address, Command: readMemoryLocationCmd})
+	}
+	return frames
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+func TestDAPC(t *testing.T) {
+	got := DAPC(0x01, 0x80)
+	want := []daliframe.ForwardFrame{{Address: 0x01, Command: 0x80}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DAPC() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGoToScene(t *testing.T) {
+	got := GoToScene(0x01, 5)
+	want := []daliframe.ForwardFrame{{Address: 0x01, Command: 0x15}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GoToScene() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetScene(t *testing.T) {
+	got := SetScene(0x01, 5, 0xFE)
+	want := []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: 0xFE},
+		{Address: 0x01, Command: 0x45},
+		{Address: 0x01, Command: 0x45},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SetScene() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAddToGroupSendsTwice(t *testing.T) {
+	got := AddToGroup(0x01, 3)
+	want := []daliframe.ForwardFrame{
+		{Address: 0x01, Command: 0x63},
+		{Address: 0x01, Command: 0x63},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("AddToGroup() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadMemoryBank(t *testing.T) {
+	got := ReadMemoryBank(0x01, 0, 0x10, 3)
+	want := []daliframe.ForwardFrame{
+		{Address: dtr1Addr, Command: 0},
+		{Address: dtr0Addr, Command: 0x10},
+		{Address: 0x01, Command: readMemoryLocationCmd},
+		{Address: 0x01, Command: readMemoryLocationCmd},
+		{Address: 0x01, Command: readMemoryLocationCmd},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadMemoryBank() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : DT8 (IEC 62386-209) colour control. Every colour value is
+ *    					 set the same way the parent cmd package sets scenes and
+ *	 						 fade timing: write the value into DTR0 (and DTR1 for 16
+ *							 bit values), then trigger the matching extended command,
+ * 							 finishing with ACTIVATE so multi-channel colours (xy,
+ *							 RGBWAF) apply atomically instead of channel-by-channel.
+ *
+ */
+
+package dt8
+
+import "github.com/ThomasGeor/dali/daliframe"
+
+// special commands (IEC 62386-102 §11.3) used to stage DTR0/DTR1 before a
+// DT8 extended command.
+const (
+	dtr0Addr uint8 = 0b10100011
+	dtr1Addr uint8 = 0b11000011
+)
+
+// DT8 extended colour commands (IEC 62386-209 table 15).
+const (
+	setTemperatureCmd uint8 = 0xE7 // SET TEMPERATURE Tc, mirek from DTR0 (low) / DTR1 (high)
+	setXCoordinateCmd uint8 = 0xE8 // SET COLOUR VALUE: X, from DTR0 (low) / DTR1 (high)
+	setYCoordinateCmd uint8 = 0xE9 // SET COLOUR VALUE: Y, from DTR0 (low) / DTR1 (high)
+	setChannelBaseCmd uint8 = 0xEA // SET COLOUR VALUE: RGBWAF channel 0 (red) .. 5 (free colour), level from DTR0
+	activateCmd       uint8 = 0xE2 // ACTIVATE: apply the colour value(s) just staged
+)
+
+// SetTemperatureKelvin sets address's correlated colour temperature.
+// mirek is the DALI wire value in mirek (reciprocal megakelvin); convert
+// from Kelvin with mirek = 1_000_000/kelvin.
+func SetTemperatureKelvin(address uint8, mirek uint16) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: uint8(mirek)},
+		{Address: dtr1Addr, Command: uint8(mirek >> 8)},
+		{Address: address, Command: setTemperatureCmd},
+		{Address: address, Command: activateCmd},
+	}
+}
+
+// SetXY sets address's colour via CIE 1931 xy chromaticity coordinates,
+// each a 16 bit value spanning 0-1 (0xFFFF == 1.0).
+func SetXY(address uint8, x, y uint16) []daliframe.ForwardFrame {
+	return []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: uint8(x)},
+		{Address: dtr1Addr, Command: uint8(x >> 8)},
+		{Address: address, Command: setXCoordinateCmd},
+		{Address: dtr0Addr, Command: uint8(y)},
+		{Address: dtr1Addr, Command: uint8(y >> 8)},
+		{Address: address, Command: setYCoordinateCmd},
+		{Address: address, Command: activateCmd},
+	}
+}
+
+// SetRGBWAF sets address's 6 colour channels (red, green, blue, white,
+// amber, free colour), each an 8 bit level.
+func SetRGBWAF(address uint8, red, green, blue, white, amber, free uint8) []daliframe.ForwardFrame {
+	levels := [6]uint8{red, green, blue, white, amber, free}
+	frames := make([]daliframe.ForwardFrame, 0, len(levels)*2+1)
+	for channel, level := range levels {
+		frames = append(frames,
+			daliframe.ForwardFrame{Address: dtr0Addr, Command: level},
+			daliframe.ForwardFrame{Address: address, Command: setChannelBaseCmd + uint8(channel)},
+		)
+	}
+	return append(frames, daliframe.ForwardFrame{Address: address, Command: activateCmd})
+}
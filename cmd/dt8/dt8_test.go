@@ -0,0 +1,53 @@
+package dt8
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+func TestSetTemperatureKelvin(t *testing.T) {
+	got := SetTemperatureKelvin(0x01, 0x01F4) // 500 mirek == 2000K
+	want := []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: 0xF4},
+		{Address: dtr1Addr, Command: 0x01},
+		{Address: 0x01, Command: setTemperatureCmd},
+		{Address: 0x01, Command: activateCmd},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SetTemperatureKelvin() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetXY(t *testing.T) {
+	got := SetXY(0x01, 0x1234, 0x5678)
+	want := []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: 0x34},
+		{Address: dtr1Addr, Command: 0x12},
+		{Address: 0x01, Command: setXCoordinateCmd},
+		{Address: dtr0Addr, Command: 0x78},
+		{Address: dtr1Addr, Command: 0x56},
+		{Address: 0x01, Command: setYCoordinateCmd},
+		{Address: 0x01, Command: activateCmd},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SetXY() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetRGBWAF(t *testing.T) {
+	got := SetRGBWAF(0x01, 10, 20, 30, 40, 50, 60)
+	want := []daliframe.ForwardFrame{
+		{Address: dtr0Addr, Command: 10}, {Address: 0x01, Command: setChannelBaseCmd + 0},
+		{Address: dtr0Addr, Command: 20}, {Address: 0x01, Command: setChannelBaseCmd + 1},
+		{Address: dtr0Addr, Command: 30}, {Address: 0x01, Command: setChannelBaseCmd + 2},
+		{Address: dtr0Addr, Command: 40}, {Address: 0x01, Command: setChannelBaseCmd + 3},
+		{Address: dtr0Addr, Command: 50}, {Address: 0x01, Command: setChannelBaseCmd + 4},
+		{Address: dtr0Addr, Command: 60}, {Address: 0x01, Command: setChannelBaseCmd + 5},
+		{Address: 0x01, Command: activateCmd},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SetRGBWAF() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,341 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Incremental commissioning support. Ιnitialize_dali always
+ *    					 wipes and re-randomizes the whole bus, which renumbers every
+ *	 						 ballast on a second run. AddressMap persists which of the 64
+ *							 short addresses are already taken, and CommissionNew only
+ * 							 searches for and assigns addresses to units that don't have
+ *							 one yet, leaving already-commissioned ballasts untouched.
+ *
+ */
+
+package dali
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+const (
+	// special commands (IEC 62386-102 §11.3), addressed to whichever
+	// device is currently selected by the long-address search rather than
+	// by short/group address.
+	initialiseAddr      uint8 = 0b10100101
+	randomiseAddr       uint8 = 0b10100111
+	compareAddr         uint8 = 0b10101001
+	withdrawAddr        uint8 = 0b10101011
+	searchAddrH         uint8 = 0b10110001
+	searchAddrM         uint8 = 0b10110011
+	searchAddrL         uint8 = 0b10110101
+	programShortAddrCmd uint8 = 0b10110111
+	terminateAddr       uint8 = 0b10100001
+	dtr0Addr            uint8 = 0b10100011
+
+	// addressed commands (IEC 62386-102 §11.2), valid against a short
+	// address, group address or broadcast.
+	storeDTRAsShortAddressCmd  uint8 = 0x80
+	queryControlGearPresentCmd uint8 = 0x91
+)
+
+// numShortAddresses is how many short addresses DALI defines (0-63).
+const numShortAddresses = 64
+
+// AddressMap is a persisted bitmap of which of the 64 DALI short addresses
+// are already assigned, loaded from disk so CommissionNew doesn't
+// renumber ballasts that were commissioned in a previous run.
+type AddressMap struct {
+	mu   sync.Mutex
+	path string
+	bits [8]byte // 64 short addresses, one bit each
+}
+
+// LoadAddressMap reads the bitmap from path. A missing file is treated as
+// an empty map (no addresses assigned yet), which is the normal state for
+// a brand new installation.
+func LoadAddressMap(path string) (*AddressMap, error) {
+	m := &AddressMap{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dali: load address map: %w", err)
+	}
+	if len(data) != len(m.bits) {
+		return nil, fmt.Errorf("dali: address map %q is %d bytes, want %d", path, len(data), len(m.bits))
+	}
+	copy(m.bits[:], data)
+	return m, nil
+}
+
+func (m *AddressMap) save() error {
+	if m.path == "" {
+		return nil
+	}
+	if err := os.WriteFile(m.path, m.bits[:], 0644); err != nil {
+		return fmt.Errorf("dali: save address map: %w", err)
+	}
+	return nil
+}
+
+// InUse reports whether addr is marked as already assigned.
+func (m *AddressMap) InUse(addr uint8) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inUseLocked(addr)
+}
+
+func (m *AddressMap) inUseLocked(addr uint8) bool {
+	return m.bits[addr/8]&(1<<(addr%8)) != 0
+}
+
+// NextFree returns the lowest short address not yet marked in use.
+func (m *AddressMap) NextFree() (addr uint8, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for a := uint8(0); a < numShortAddresses; a++ {
+		if !m.inUseLocked(a) {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+func (m *AddressMap) mark(addr uint8) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bits[addr/8] |= 1 << (addr % 8)
+	return m.save()
+}
+
+func (m *AddressMap) unmark(addr uint8) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bits[addr/8] &^= 1 << (addr % 8)
+	return m.save()
+}
+
+// sendAndWait issues frame and blocks for its Response, bridging the
+// Driver's non-blocking Send into the synchronous steps commissioning
+// requires.
+func (d *Driver) sendAndWait(ctx context.Context, frame daliframe.ForwardFrame) (Response, error) {
+	reply, err := d.Send(ctx, frame)
+	if err != nil {
+		return Response{}, err
+	}
+	select {
+	case resp := <-reply:
+		return resp, resp.Err
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+}
+
+func addressByte(short uint8) uint8 {
+	return 1 + (short << 1)
+}
+
+func splitLongAddress(addr int64) (high, mid, low uint8) {
+	return uint8(addr >> 16), uint8(addr >> 8), uint8(addr)
+}
+
+// CommissionNew issues INITIALISE with the "devices without a short
+// address" flag (0xFF) so already-addressed ballasts stay silent, then
+// runs the IEC 62386-102 §9.3 binary search for long addresses, assigning
+// each newly found device the next free slot from addrs. It returns the
+// short addresses it assigned.
+func (d *Driver) CommissionNew(ctx context.Context, addrs *AddressMap) ([]uint8, error) {
+	const devicesWithoutShortAddress = 0xFF
+
+	for _, step := range []uint8{initialiseAddr, initialiseAddr} {
+		// Driver.Send already settles for timing.TwiceInterval after the
+		// first INITIALISE and timing.SettleAfterConfig after the second
+		// (Driver.settle recognizes the repeated frame as completing the
+		// send-twice pair), so INITIALISE has taken effect by the time this
+		// loop returns.
+		if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: step, Command: devicesWithoutShortAddress}); err != nil {
+			return nil, fmt.Errorf("dali: commission: initialise: %w", err)
+		}
+	}
+
+	for _, step := range []uint8{randomiseAddr, randomiseAddr} {
+		// Same reasoning as above: Driver.settle gives RANDOMISE's second
+		// send the full SettleAfterConfig wait before this returns.
+		if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: step, Command: 0}); err != nil {
+			return nil, fmt.Errorf("dali: commission: randomise: %w", err)
+		}
+	}
+
+	var assigned []uint8
+	for {
+		long, found, err := d.searchLowestLongAddress(ctx)
+		if err != nil {
+			return assigned, fmt.Errorf("dali: commission: search: %w", err)
+		}
+		if !found {
+			break
+		}
+
+		addr, ok := addrs.NextFree()
+		if !ok {
+			break // address map is full; stop searching (the unit just found keeps no short address)
+		}
+		if err := d.programShortAddress(ctx, long, addr); err != nil {
+			return assigned, fmt.Errorf("dali: commission: assign %d: %w", addr, err)
+		}
+		if err := addrs.mark(addr); err != nil {
+			return assigned, err
+		}
+		assigned = append(assigned, addr)
+	}
+
+	if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: terminateAddr, Command: 0}); err != nil {
+		return assigned, fmt.Errorf("dali: commission: terminate: %w", err)
+	}
+	return assigned, nil
+}
+
+// searchLowestLongAddress runs the IEC 62386-102 binary search/compare
+// loop and leaves the lowest-addressed responding device selected
+// (withdrawn from future compares), reporting its long address.
+func (d *Driver) searchLowestLongAddress(ctx context.Context) (long int64, found bool, err error) {
+	const lowest, highest = 0x000000, 0xFFFFFF
+	low, high := int64(lowest), int64(highest)
+	mid := (low + high) / 2
+
+	for high-low > 1 {
+		if err := d.sendSearchAddress(ctx, mid); err != nil {
+			return 0, false, err
+		}
+		resp, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: compareAddr, Command: 0})
+		if err != nil {
+			return 0, false, err
+		}
+		if resp.Ok {
+			high = mid // at least one device answered: it's in [low, mid]
+		} else {
+			low = mid
+		}
+		mid = (low + high) / 2
+	}
+
+	if high == highest {
+		return 0, false, nil // no device responded anywhere in the range
+	}
+
+	long = mid + 1
+	if err := d.sendSearchAddress(ctx, long); err != nil {
+		return 0, false, err
+	}
+	if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: withdrawAddr, Command: 0}); err != nil {
+		return 0, false, err
+	}
+	return long, true, nil
+}
+
+func (d *Driver) sendSearchAddress(ctx context.Context, long int64) error {
+	high, mid, low := splitLongAddress(long)
+	steps := [3]struct {
+		addr uint8
+		data uint8
+	}{
+		{searchAddrH, high},
+		{searchAddrM, mid},
+		{searchAddrL, low},
+	}
+	for _, step := range steps {
+		if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: step.addr, Command: step.data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// programShortAddress assigns short to the device currently selected by
+// long (see searchLowestLongAddress), waits SettleAfterConfig and then
+// withdraws it. It does not query the device on its new short address.
+func (d *Driver) programShortAddress(ctx context.Context, long int64, short uint8) error {
+	if err := d.sendSearchAddress(ctx, long); err != nil {
+		return err
+	}
+	if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: programShortAddrCmd, Command: addressByte(short)}); err != nil {
+		return err
+	}
+	// The driver already waited timing.TwiceInterval after the send above;
+	// the new short address needs the full settle time before it's relied
+	// on (e.g. a QueryPresent shortly after commissioning).
+	time.Sleep(d.timing.SettleAfterConfig)
+	if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: withdrawAddr, Command: 0}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveShortAddress clears addr's short address (STORE DTR AS SHORT
+// ADDRESS with DTR0 = 0xFF, IEC 62386-102 §11.2), so the ballast stays on
+// the bus but is no longer reachable by short address, and unmarks it in
+// addrs so CommissionNew will re-find and re-number it.
+func (d *Driver) RemoveShortAddress(ctx context.Context, addrs *AddressMap, addr uint8) error {
+	const clearShortAddress = 0xFF
+	if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: dtr0Addr, Command: clearShortAddress}); err != nil {
+		return fmt.Errorf("dali: remove short address %d: set DTR0: %w", addr, err)
+	}
+	for i := 0; i < 2; i++ {
+		// STORE DTR AS SHORT ADDRESS only takes effect once the gear sees
+		// the exact same frame twice within the "send twice" window (same
+		// family as every STORE/ADD/REMOVE command cmd.go's sendTwice
+		// wraps); a single send is silently ignored by real hardware.
+		if _, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: addressByte(addr), Command: storeDTRAsShortAddressCmd}); err != nil {
+			return fmt.Errorf("dali: remove short address %d: %w", addr, err)
+		}
+	}
+	// Driver.settle already gives the second send above the full
+	// SettleAfterConfig wait (it recognizes the repeated frame as
+	// completing the send-twice pair), so the short address is actually
+	// cleared on the bus by the time addrs is updated to match.
+	return addrs.unmark(addr)
+}
+
+// QueryPresent reports whether a device currently answers on short
+// address addr.
+func (d *Driver) QueryPresent(ctx context.Context, addr uint8) (bool, error) {
+	resp, err := d.sendAndWait(ctx, daliframe.ForwardFrame{Address: addressByte(addr), Command: queryControlGearPresentCmd})
+	if err != nil {
+		return false, fmt.Errorf("dali: query present %d: %w", addr, err)
+	}
+	return resp.Ok, nil
+}
+
+// Rescan reconciles addrs with what's physically present on the bus,
+// returning the short addresses that were found where the map had none
+// (added) and those the map had but which no longer answer (removed).
+func (d *Driver) Rescan(ctx context.Context, addrs *AddressMap) (added, removed []uint8, err error) {
+	for a := uint8(0); a < numShortAddresses; a++ {
+		present, err := d.QueryPresent(ctx, a)
+		if err != nil {
+			return added, removed, err
+		}
+		wasMarked := addrs.InUse(a)
+		switch {
+		case present && !wasMarked:
+			if err := addrs.mark(a); err != nil {
+				return added, removed, err
+			}
+			added = append(added, a)
+		case !present && wasMarked:
+			if err := addrs.unmark(a); err != nil {
+				return added, removed, err
+			}
+			removed = append(removed, a)
+		}
+	}
+	return added, removed, nil
+}
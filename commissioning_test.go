@@ -0,0 +1,121 @@
+package dali
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+func TestAddressMapLoadMissingFileIsEmpty(t *testing.T) {
+	m, err := LoadAddressMap(filepath.Join(t.TempDir(), "missing.bin"))
+	if err != nil {
+		t.Fatalf("LoadAddressMap() error: %v", err)
+	}
+	if addr, ok := m.NextFree(); !ok || addr != 0 {
+		t.Fatalf("NextFree() = (%d,%v), want (0,true) on an empty map", addr, ok)
+	}
+}
+
+func TestAddressMapMarkPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addrs.bin")
+
+	m, err := LoadAddressMap(path)
+	if err != nil {
+		t.Fatalf("LoadAddressMap() error: %v", err)
+	}
+	if err := m.mark(5); err != nil {
+		t.Fatalf("mark() error: %v", err)
+	}
+
+	reloaded, err := LoadAddressMap(path)
+	if err != nil {
+		t.Fatalf("LoadAddressMap() (reload) error: %v", err)
+	}
+	if !reloaded.InUse(5) {
+		t.Fatal("reloaded map does not have address 5 marked in use")
+	}
+	if addr, _ := reloaded.NextFree(); addr != 0 {
+		t.Fatalf("NextFree() = %d, want 0 (still free)", addr)
+	}
+
+	if err := reloaded.unmark(5); err != nil {
+		t.Fatalf("unmark() error: %v", err)
+	}
+	if reloaded.InUse(5) {
+		t.Fatal("address 5 still marked in use after unmark")
+	}
+}
+
+func TestAddressMapNextFreeExhausted(t *testing.T) {
+	m := &AddressMap{}
+	for a := 0; a < numShortAddresses; a++ {
+		m.bits[a/8] |= 1 << (a % 8)
+	}
+	if _, ok := m.NextFree(); ok {
+		t.Fatal("NextFree() ok = true on a fully assigned map")
+	}
+}
+
+// scriptedTransport answers each SendForward/ReceiveBackward pair by
+// pattern-matching on the forward frame's address byte, so a
+// commissioning test can simulate a single unaddressed ballast without
+// real hardware.
+type scriptedTransport struct {
+	reply func(frame daliframe.ForwardFrame) (daliframe.BackwardFrame, bool, error)
+	last  daliframe.ForwardFrame
+}
+
+func (s *scriptedTransport) SendForward(ctx context.Context, frame daliframe.ForwardFrame) error {
+	s.last = frame
+	return nil
+}
+
+func (s *scriptedTransport) ReceiveBackward(ctx context.Context, timeout time.Duration) (daliframe.BackwardFrame, bool, error) {
+	return s.reply(s.last)
+}
+
+func (s *scriptedTransport) Close() error { return nil }
+
+func TestCommissionNewAssignsLowestFreeAddress(t *testing.T) {
+	// Simulate exactly one unaddressed device: it answers every COMPARE
+	// until it's withdrawn, which is enough to pin the binary search to
+	// the bottom of the range (long address 1) regardless of which half
+	// it probes.
+	withdrawn := false
+
+	transport := &scriptedTransport{}
+	transport.reply = func(frame daliframe.ForwardFrame) (daliframe.BackwardFrame, bool, error) {
+		switch frame.Address {
+		case compareAddr:
+			if withdrawn {
+				return daliframe.BackwardFrame{}, false, nil
+			}
+			return daliframe.BackwardFrame{Data: 0xFF}, true, nil
+		case withdrawAddr:
+			withdrawn = true
+			return daliframe.BackwardFrame{}, false, nil
+		default:
+			return daliframe.BackwardFrame{}, false, nil
+		}
+	}
+
+	d := NewDriver(transport)
+	defer d.Close()
+
+	addrs := &AddressMap{}
+	addrs.bits[0] = 0b00000001 // address 0 already taken; next free is 1
+
+	assigned, err := d.CommissionNew(context.Background(), addrs)
+	if err != nil {
+		t.Fatalf("CommissionNew() error: %v", err)
+	}
+	if len(assigned) != 1 || assigned[0] != 1 {
+		t.Fatalf("CommissionNew() assigned %v, want [1]", assigned)
+	}
+	if !addrs.InUse(1) {
+		t.Fatal("address 1 not marked in use after CommissionNew")
+	}
+}
@@ -27,7 +27,6 @@ var (
 	baudrate int
 	databits int
 	stopbits int
-	parity   string
 	message  []byte
 )
 
@@ -64,6 +63,10 @@ type Port interface {
  *	@return 	1. error value which indicates if the connection was successful or not
  *						2. port struct which contains information about the serial port
  *							 edgeX connected to
+ *
+ * Deprecated: use NewSerialTransport with an already-open Port and drive
+ * it through Driver instead. This function killed the whole process via
+ * log.Fatal on any dial error, which a library must never do.
  */
 
 func Create_Serial_Connection (serial_address string) (Port, error){
@@ -90,7 +93,7 @@ func Create_Serial_Connection (serial_address string) (Port, error){
 	log.Printf("connecting %+v", config)
 	port, err := serial.Open(&config)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	return port,nil
@@ -101,6 +104,10 @@ func Create_Serial_Connection (serial_address string) (Port, error){
  *	@param 		port : a port struct object containing information of the port we want to
  *								   disconnect from
  *	@return 	error value which indicates if the connection closing was successful or not
+ *
+ * Deprecated: Driver.Close already closes its underlying Transport. This
+ * function killed the whole process via log.Fatal on a close error,
+ * which a library must never do.
  */
 
 func Close_serial_connection (port Port) (error){
@@ -108,7 +115,7 @@ func Close_serial_connection (port Port) (error){
 	// close the connection after the write/read procedure has finished.
 	err := port.Close()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	log.Println("closed")
 	return err
@@ -123,7 +130,10 @@ func is_bit_set(n uint8, pos int) bool {
 }
 
 /*
- *	@Brief	 	creates a Dali frame to be sent (forward frame)
+ *	@Brief	 	creates a Dali frame to be sent (forward frame). This is the
+ *						legacy UART packing used by SerialTransport; the proper
+ *						Manchester line encoding lives in the daliframe package
+ *						(daliframe.ForwardFrame) and is what new code should use.
  *  @param 		address : 8 bit address of the DALI device to command
  * 	@param		command : 8 bit specific command request (there are predefined commands)
  *	@return		DALI message frame command in bytes
@@ -147,7 +157,7 @@ func create_dali_frame(dali_address uint8, dali_command uint8) ([]byte) {
 			dali_convert = dali_command
 		}
 
-		for i := 7; i < 0; i++ {
+		for i := 7; i >= 0; i-- {
 
 			if msg_cn < 0{
 				msg_cn = 7; // reset in order to support the next byte
@@ -180,6 +190,10 @@ func create_dali_frame(dali_address uint8, dali_command uint8) ([]byte) {
  *	@param 		dali_address : the short address of the device we want to command
  *	@param 		dali_command : the command which we want to be executed (encoded)
  *	@return 	error value which indicates if the command reached the device or not
+ *
+ * Deprecated: use Driver.Send with a daliframe.ForwardFrame instead. This
+ * function killed the whole process via log.Fatal on a write error,
+ * which a library must never do.
  */
 
 func Ιssue_dali_request(port Port,dali_address uint8, dali_command uint8) (err error){
@@ -191,10 +205,9 @@ func Ιssue_dali_request(port Port,dali_address uint8, dali_command uint8) (err
 
   // send the message specifically for Dali implementation
 	if _, err = port.Write(message); err != nil {
-		log.Fatal(err)
 		return err
 	}else{
-		log.Println("sent :%v",message)
+		log.Printf("sent :%v",message)
 	}
 
  return nil
@@ -204,6 +217,10 @@ func Ιssue_dali_request(port Port,dali_address uint8, dali_command uint8) (err
  *	@Brief	 	Wait for a DALI backward frame
  *	@param 		port : a port struct object containing information of the port to receive the command
  *	@return 	error value which indicates if the command reached the device or not
+ *
+ * Deprecated: use Driver.Send and read the Response it replies with
+ * instead. This function killed the whole process via log.Fatal on a
+ * read error, which a library must never do.
  */
 
 func Wait_dali_response(port Port) ([]byte,error){
@@ -214,9 +231,9 @@ func Wait_dali_response(port Port) ([]byte,error){
 
   // wait for the DALI response
 	if _, err = port.Read(response); err != nil {
-		log.Fatal(err)
+		return response, err
 	}else{
-		log.Println("read : %v",response)
+		log.Printf("read : %v",response)
 	}
 
  return response,nil
@@ -224,7 +241,9 @@ func Wait_dali_response(port Port) ([]byte,error){
 
 
 /*
- *	@Brief	 	Scan for short addresses from the DALI-bus
+ *	@Brief	 	Scan for short addresses from the DALI-bus. Waits between
+ *						commands use DefaultTiming rather than a blanket 10 ms, so
+ *						the wait matches what the frame just sent actually needs.
  *	@return 	error value which indicates if the command reached the device or not
  *						short addresses byte string
  */
@@ -238,9 +257,11 @@ func Scan_addresses(port Port) ([]byte,error){
 	var address_byte			uint8
 	var device_short_add	uint8
 
+	timing := DefaultTiming()
+
 	// turn off broadcast
 	err := Ιssue_dali_request(port,BROADCAST_C, OFF_C)
-	time.Sleep(10 * time.Millisecond)
+	time.Sleep(timing.ForwardToForward) // blind command, no backward frame expected
 
 	for device_short_add = 0;device_short_add < 64;device_short_add++{
 
@@ -248,7 +269,7 @@ func Scan_addresses(port Port) ([]byte,error){
 		address_byte = 1 +(device_short_add << 1)
 
 		err = Ιssue_dali_request(port,address_byte, 0xA1)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.ForwardToBackward) // give a device time to reply before reading
 
 		single_response,err = Wait_dali_response(port)
 		// cheack in the 3 bytes response if a logic 0 was received
@@ -258,9 +279,9 @@ func Scan_addresses(port Port) ([]byte,error){
 
 			// if a 0 was received that means that a device responded
 			err = Ιssue_dali_request(port,address_byte, ON_C)
-			time.Sleep(10 * time.Millisecond)
+			time.Sleep(timing.ForwardToForward)
 			err = Ιssue_dali_request(port,address_byte, OFF_C)
-			time.Sleep(10 * time.Millisecond)
+			time.Sleep(timing.ForwardToForward)
 
 			response[short_addresses] = address_byte
 			short_addresses++
@@ -271,7 +292,7 @@ func Scan_addresses(port Port) ([]byte,error){
 
 	// turn on the broadcast
 	err = Ιssue_dali_request(port,BROADCAST_C, ON_C)
-	time.Sleep(10 * time.Millisecond)
+	time.Sleep(timing.ForwardToForward)
 
  	return response,err
 }
@@ -289,7 +310,9 @@ func split_address(input int64) (uint8 ,uint8 ,uint8){
 }
 
 /*
- *	@Brief	 	Standard initialization of the DALI interface
+ *	@Brief	 	Standard initialization of the DALI interface. Waits between
+ *						commands use DefaultTiming rather than a blanket 10 ms, so
+ *						the wait matches what the frame just sent actually needs.
  *	@return 	error value which indicates if the initialization commands
  *						reached the devices or not
  */
@@ -301,27 +324,29 @@ func Ιnitialize_dali(port Port) error {
 		var longadd 		 int64	= (low_longadd + high_longadd) / 2
 		var short_add    uint8
 
+		timing := DefaultTiming()
+
 		log.Println("initializating DALI bus")
 
 		// reset the DALI devices
 		err := Ιssue_dali_request(port,BROADCAST_C, RESET)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.TwiceInterval) // gap before the repeated RESET
 		err = Ιssue_dali_request(port,BROADCAST_C, RESET)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.SettleAfterConfig) // let the reset take effect
 		err = Ιssue_dali_request(port,BROADCAST_C, OFF_C)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.ForwardToForward)
 
 		// Initialize the DALI devices
 		err = Ιssue_dali_request(port,0b10100101, 0b00000000)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.TwiceInterval) // gap before the repeated INITIALISE
 		err = Ιssue_dali_request(port,0b10100101, 0b00000000)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.SettleAfterConfig) // let INITIALISE take effect
 
 		// Randomize the DALI devices
 		err = Ιssue_dali_request(port,0b10100111, 0b00000000)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.TwiceInterval) // gap before the repeated RANDOMISE
 		err = Ιssue_dali_request(port,0b10100111, 0b00000000)
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.SettleAfterConfig) // let RANDOMISE take effect
 
 		// When don't need to wait for responses after issuing these commands
 		// since the devices are not initialized yet and produce no response
@@ -333,12 +358,13 @@ func Ιnitialize_dali(port Port) error {
 
 				highbyte,middlebyte,lowbyte := split_address(longadd)
 				err = Ιssue_dali_request(port,0b10110001, highbyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10110001, middlebyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10110001, lowbyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10101001, 0b00000000) //compare
+				time.Sleep(timing.ForwardToBackward) // give any matching device time to reply
 
 				rsp,rsp_err := Wait_dali_response(port)
 				if rsp_err != nil{
@@ -366,19 +392,19 @@ func Ιnitialize_dali(port Port) error {
 				// Assigning a short address
 				highbyte,middlebyte,lowbyte := split_address(longadd + 1)
 				err = Ιssue_dali_request(port,0b10110001, highbyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10110001, middlebyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10110001, lowbyte)
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,0b10110111, 1 + (short_add << 1))
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.SettleAfterConfig) // let PROGRAM SHORT ADDRESS take effect
 				err = Ιssue_dali_request(port,0b10101011,0b00000000) //withdraw
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,1 + (short_add << 1), ON_C) //withdraw
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				err = Ιssue_dali_request(port,1 + (short_add << 1), OFF_C) //withdraw
-				time.Sleep(10 * time.Millisecond)
+				time.Sleep(timing.ForwardToForward)
 				short_add++
 
 				// reload the high value
@@ -391,9 +417,9 @@ func Ιnitialize_dali(port Port) error {
 		} // end of ssigning short addresses
 
 		err = Ιssue_dali_request(port,0b10100001,0b00000000) //terminate
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.ForwardToForward)
 		err = Ιssue_dali_request(port,BROADCAST_C,ON_C) //broadcast on
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(timing.ForwardToForward)
 
 		return err
 }
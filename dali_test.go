@@ -0,0 +1,54 @@
+package dali
+
+import "testing"
+
+// wantCreateDaliFrame independently packs address/command into the legacy
+// 5 byte UART frame create_dali_frame produces, so the test doesn't just
+// re-run the same (previously broken) loop against itself: start bit
+// fixed to logical 1 (0,1), then each of the 16 address/command bits
+// written MSB-first as 1->(0,1), 0->(1,0), matching the IEC 62386 "1 bit
+// means a transition from low to high" Manchester convention. The
+// remaining unused bits of the 5 byte buffer stay zero.
+func wantCreateDaliFrame(address, command uint8) []byte {
+	buf := make([]byte, 5)
+	pos := 0
+	writeBit := func(v bool) {
+		if v {
+			buf[pos/8] |= 1 << uint(7-pos%8)
+		}
+		pos++
+	}
+
+	writeBit(false)
+	writeBit(true) // start bit: logical 1
+
+	for _, b := range [2]uint8{address, command} {
+		for i := 7; i >= 0; i-- {
+			if b&(1<<uint(i)) != 0 {
+				writeBit(false)
+				writeBit(true)
+			} else {
+				writeBit(true)
+				writeBit(false)
+			}
+		}
+	}
+
+	return buf
+}
+
+func TestCreateDaliFrame(t *testing.T) {
+	const address = 0b10100101 // a representative special command address
+	for command := 0; command < 256; command++ {
+		got := create_dali_frame(address, uint8(command))
+		want := wantCreateDaliFrame(address, uint8(command))
+		if len(got) != len(want) {
+			t.Fatalf("create_dali_frame(%#x, %#x): got %d bytes, want %d", address, command, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("create_dali_frame(%#x, %#x) = % 08b, want % 08b", address, command, got, want)
+			}
+		}
+	}
+}
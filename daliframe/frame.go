@@ -0,0 +1,226 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Manchester encoding/decoding of IEC 62386 DALI frames.
+ *    					 This package is transport-agnostic: it turns a ForwardFrame
+ *	 						 or BackwardFrame into a stream of line-level samples (and
+ *							 back again) so that any transport (UART hack, bit-banged
+ * 							 GPIO, ...) only has to deal with sampled levels instead of
+ *							 re-implementing the Manchester bit timing itself.
+ *
+ */
+
+package daliframe
+
+import "fmt"
+
+// HalfBit is one Manchester half-bit period: a single line level (High or
+// Low) held for one DALI half-bit time (416.67 µs at the standard 1200 Bd
+// DALI bit rate, i.e. 833 µs per full DALI bit).
+type HalfBit uint8
+
+const (
+	Low  HalfBit = 0
+	High HalfBit = 1
+)
+
+const (
+	// ForwardHalfBits is the length of a Manchester-encoded forward frame:
+	// start bit + 16 data bits + 2 stop bits (2 + 32 + 4 = 38).
+	ForwardHalfBits = 38
+	// BackwardHalfBits is the length of a Manchester-encoded backward
+	// frame: start bit + 8 data bits + 2 stop bits (2 + 16 + 4 = 22).
+	BackwardHalfBits = 22
+)
+
+// ForwardFrame is a master-to-slave DALI frame: an 8 bit address byte
+// followed by an 8 bit command/data byte (IEC 62386-101 table 3).
+type ForwardFrame struct {
+	Address uint8
+	Command uint8
+}
+
+// BackwardFrame is a slave-to-master DALI response frame: a single 8 bit
+// data byte (IEC 62386-101 table 4).
+type BackwardFrame struct {
+	Data uint8
+}
+
+// Encode returns the forward frame as a Manchester bitstream sampled at
+// oversample samples per half-bit. Each returned byte is 0 or 1 and is one
+// sample of the line level, so callers driving a GPIO pin can play the
+// stream back one sample per timer tick.
+func (f ForwardFrame) Encode(oversample int) []byte {
+	return encode(f.halfBits(), oversample)
+}
+
+// DecodeForwardFrame reverses Encode, recovering the forward frame from a
+// bitstream sampled at oversample samples per half-bit.
+func DecodeForwardFrame(samples []byte, oversample int) (ForwardFrame, error) {
+	hb, err := decodeHalfBits(samples, oversample, ForwardHalfBits)
+	if err != nil {
+		return ForwardFrame{}, err
+	}
+	if err := expectStart(hb); err != nil {
+		return ForwardFrame{}, err
+	}
+	address, err := decodeByte(hb[2:18])
+	if err != nil {
+		return ForwardFrame{}, fmt.Errorf("daliframe: address: %w", err)
+	}
+	command, err := decodeByte(hb[18:34])
+	if err != nil {
+		return ForwardFrame{}, fmt.Errorf("daliframe: command: %w", err)
+	}
+	if err := expectStop(hb[34:38]); err != nil {
+		return ForwardFrame{}, err
+	}
+	return ForwardFrame{Address: address, Command: command}, nil
+}
+
+// Encode returns the backward frame as a Manchester bitstream sampled at
+// oversample samples per half-bit.
+func (f BackwardFrame) Encode(oversample int) []byte {
+	return encode(f.halfBits(), oversample)
+}
+
+// DecodeBackwardFrame reverses Encode, recovering the backward frame from
+// a bitstream sampled at oversample samples per half-bit.
+func DecodeBackwardFrame(samples []byte, oversample int) (BackwardFrame, error) {
+	hb, err := decodeHalfBits(samples, oversample, BackwardHalfBits)
+	if err != nil {
+		return BackwardFrame{}, err
+	}
+	if err := expectStart(hb); err != nil {
+		return BackwardFrame{}, err
+	}
+	data, err := decodeByte(hb[2:18])
+	if err != nil {
+		return BackwardFrame{}, fmt.Errorf("daliframe: data: %w", err)
+	}
+	if err := expectStop(hb[18:22]); err != nil {
+		return BackwardFrame{}, err
+	}
+	return BackwardFrame{Data: data}, nil
+}
+
+func (f ForwardFrame) halfBits() []HalfBit {
+	hb := make([]HalfBit, 0, ForwardHalfBits)
+	hb = appendBit(hb, true) // start bit is always logic 1
+	hb = appendByte(hb, f.Address)
+	hb = appendByte(hb, f.Command)
+	hb = append(hb, High, High, High, High) // 2 stop bits, idle high, 2 half-bits each
+	return hb
+}
+
+func (f BackwardFrame) halfBits() []HalfBit {
+	hb := make([]HalfBit, 0, BackwardHalfBits)
+	hb = appendBit(hb, true) // start bit is always logic 1
+	hb = appendByte(hb, f.Data)
+	hb = append(hb, High, High, High, High) // 2 stop bits, idle high, 2 half-bits each
+	return hb
+}
+
+// appendBit Manchester-encodes a single DALI data bit. IEC 62386 sends a
+// logic 0 as High-then-Low and a logic 1 as Low-then-High.
+func appendBit(hb []HalfBit, bit bool) []HalfBit {
+	if bit {
+		return append(hb, Low, High)
+	}
+	return append(hb, High, Low)
+}
+
+func appendByte(hb []HalfBit, b uint8) []HalfBit {
+	for i := 7; i >= 0; i-- {
+		hb = appendBit(hb, b&(1<<uint(i)) != 0)
+	}
+	return hb
+}
+
+// CollisionError reports that a half-bit pair failed to decode as a valid
+// Manchester transition (both half-bits at the same level). On a DALI bus
+// this is the usual signature of a collision: two or more slaves driving
+// the line at once.
+type CollisionError struct {
+	a, b HalfBit
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("daliframe: invalid manchester transition (%d,%d), likely a bus collision", e.a, e.b)
+}
+
+// decodeBit recovers the data bit from a pair of half-bits, reporting a
+// *CollisionError if the pair isn't a valid Manchester transition.
+func decodeBit(a, b HalfBit) (bool, error) {
+	switch {
+	case a == High && b == Low:
+		return false, nil
+	case a == Low && b == High:
+		return true, nil
+	default:
+		return false, &CollisionError{a: a, b: b}
+	}
+}
+
+func decodeByte(hb []HalfBit) (uint8, error) {
+	var b uint8
+	for i := 0; i < 8; i++ {
+		bit, err := decodeBit(hb[i*2], hb[i*2+1])
+		if err != nil {
+			return 0, err
+		}
+		if bit {
+			b |= 1 << uint(7-i)
+		}
+	}
+	return b, nil
+}
+
+func expectStart(hb []HalfBit) error {
+	bit, err := decodeBit(hb[0], hb[1])
+	if err != nil {
+		return fmt.Errorf("daliframe: start bit: %w", err)
+	}
+	if !bit {
+		return fmt.Errorf("daliframe: start bit was logic 0")
+	}
+	return nil
+}
+
+func expectStop(hb []HalfBit) error {
+	for _, s := range hb {
+		if s != High {
+			return fmt.Errorf("daliframe: stop half-bits not idle high")
+		}
+	}
+	return nil
+}
+
+func encode(halfBits []HalfBit, oversample int) []byte {
+	out := make([]byte, 0, len(halfBits)*oversample)
+	for _, hb := range halfBits {
+		for i := 0; i < oversample; i++ {
+			out = append(out, byte(hb))
+		}
+	}
+	return out
+}
+
+// decodeHalfBits collapses an oversampled bitstream back down to one
+// HalfBit per sample group by reading the sample at the center of each
+// group, where the line level has had the most time to settle.
+func decodeHalfBits(samples []byte, oversample, want int) ([]HalfBit, error) {
+	if oversample <= 0 {
+		return nil, fmt.Errorf("daliframe: oversample must be positive")
+	}
+	if len(samples) != want*oversample {
+		return nil, fmt.Errorf("daliframe: expected %d samples at %dx oversample, got %d", want*oversample, oversample, len(samples))
+	}
+	hb := make([]HalfBit, want)
+	center := oversample / 2
+	for i := 0; i < want; i++ {
+		hb[i] = HalfBit(samples[i*oversample+center])
+	}
+	return hb, nil
+}
@@ -0,0 +1,58 @@
+package daliframe
+
+import "testing"
+
+func TestForwardFrameRoundTrip(t *testing.T) {
+	const oversample = 4
+	for address := 0; address < 256; address++ {
+		for command := 0; command < 256; command++ {
+			want := ForwardFrame{Address: uint8(address), Command: uint8(command)}
+			samples := want.Encode(oversample)
+			if len(samples) != ForwardHalfBits*oversample {
+				t.Fatalf("Encode(%d,%d): got %d samples, want %d", address, command, len(samples), ForwardHalfBits*oversample)
+			}
+			got, err := DecodeForwardFrame(samples, oversample)
+			if err != nil {
+				t.Fatalf("DecodeForwardFrame(%d,%d): %v", address, command, err)
+			}
+			if got != want {
+				t.Fatalf("DecodeForwardFrame(%d,%d) = %+v, want %+v", address, command, got, want)
+			}
+		}
+	}
+}
+
+func TestBackwardFrameRoundTrip(t *testing.T) {
+	const oversample = 4
+	for data := 0; data < 256; data++ {
+		want := BackwardFrame{Data: uint8(data)}
+		samples := want.Encode(oversample)
+		if len(samples) != BackwardHalfBits*oversample {
+			t.Fatalf("Encode(%d): got %d samples, want %d", data, len(samples), BackwardHalfBits*oversample)
+		}
+		got, err := DecodeBackwardFrame(samples, oversample)
+		if err != nil {
+			t.Fatalf("DecodeBackwardFrame(%d): %v", data, err)
+		}
+		if got != want {
+			t.Fatalf("DecodeBackwardFrame(%d) = %+v, want %+v", data, got, want)
+		}
+	}
+}
+
+func TestDecodeForwardFrameRejectsBadStartBit(t *testing.T) {
+	samples := ForwardFrame{Address: 0x01, Command: 0x02}.Encode(4)
+	// Force the start bit low-low, an invalid Manchester transition.
+	for i := 0; i < 8; i++ {
+		samples[i] = 0
+	}
+	if _, err := DecodeForwardFrame(samples, 4); err == nil {
+		t.Fatal("expected an error decoding a corrupted start bit, got nil")
+	}
+}
+
+func TestDecodeHalfBitsRejectsWrongLength(t *testing.T) {
+	if _, err := DecodeForwardFrame(make([]byte, 10), 4); err == nil {
+		t.Fatal("expected an error decoding a short sample buffer, got nil")
+	}
+}
@@ -0,0 +1,275 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Driver is a non-blocking, event-driven DALI driver. A
+ *    					 single goroutine owns the Transport so that concurrent
+ *	 						 callers (e.g. an EdgeX device service commissioning new
+ *							 ballasts while also driving normal traffic) never block on
+ * 							 each other, and so that a response can always be
+ *							 correlated with the request that provoked it.
+ *
+ */
+
+package dali
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+// ErrDriverClosed is returned by Send once Close has been called.
+var ErrDriverClosed = errors.New("dali: driver is closed")
+
+// maxCollisionRetries is how many times Send retries a command after a
+// Manchester collision on the backward frame before giving up.
+const maxCollisionRetries = 2
+
+// idleListenInterval bounds how long the driver listens for an
+// unsolicited backward frame between Send calls, so a newly queued
+// request is never delayed by more than this.
+const idleListenInterval = 50 * time.Millisecond
+
+// Response is the outcome of a Send: either a backward frame a slave
+// replied with, Ok=false if the command is one no slave answers, or Err
+// if the transport failed.
+type Response struct {
+	Frame daliframe.BackwardFrame
+	Ok    bool
+	Err   error
+}
+
+// request is a queued Send() call waiting for the driver goroutine to own
+// the transport.
+type request struct {
+	ctx   context.Context
+	frame daliframe.ForwardFrame
+	reply chan Response
+}
+
+// Driver serializes access to a Transport and correlates each forward
+// frame with the backward frame (if any) it provokes.
+type Driver struct {
+	transport Transport
+	timing    Timing
+
+	requests chan request
+
+	subsMu sync.Mutex
+	subs   []chan Response
+
+	// pendingConfigFrame is the config frame d.settle most recently gave
+	// only a TwiceInterval gap to, so the next identical frame (the second
+	// half of a send-twice pair) is recognized as the one whose effect
+	// actually needs to settle. Only touched from the run() goroutine, so
+	// it needs no lock of its own.
+	pendingConfigFrame daliframe.ForwardFrame
+	hasPendingConfig   bool
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+// NewDriver starts a Driver that owns transport until Close is called,
+// using DefaultTiming for the gaps between frames.
+func NewDriver(transport Transport) *Driver {
+	return NewDriverWithTiming(transport, DefaultTiming())
+}
+
+// NewDriverWithTiming starts a Driver like NewDriver, but with a caller
+// supplied timing profile, e.g. for bus segments with slower ballasts
+// than DefaultTiming assumes.
+func NewDriverWithTiming(transport Transport, timing Timing) *Driver {
+	d := &Driver{
+		transport: transport,
+		timing:    timing,
+		requests:  make(chan request),
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Timing returns the settling profile the driver is using between frames.
+func (d *Driver) Timing() Timing {
+	return d.timing
+}
+
+// Send queues a forward frame for transmission and returns a channel that
+// receives exactly one Response once the command (and any backward frame
+// reply) completes. Send never blocks on the bus itself; it only blocks
+// until the driver goroutine accepts the request, or ctx is cancelled.
+func (d *Driver) Send(ctx context.Context, frame daliframe.ForwardFrame) (<-chan Response, error) {
+	reply := make(chan Response, 1)
+	select {
+	case d.requests <- request{ctx: ctx, frame: frame, reply: reply}:
+		return reply, nil
+	case <-d.closing:
+		return nil, ErrDriverClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Subscribe returns a channel of backward frames the driver received that
+// were not solicited by a Send call, e.g. slave-initiated events.
+// Subscribers that fall behind drop events rather than stalling the
+// driver goroutine.
+func (d *Driver) Subscribe() <-chan Response {
+	ch := make(chan Response, 16)
+	d.subsMu.Lock()
+	d.subs = append(d.subs, ch)
+	d.subsMu.Unlock()
+	return ch
+}
+
+// Close stops accepting new requests, waits for the driver goroutine to
+// finish the request it is handling (which gets its normal Response) and
+// answer any Send still waiting to be accepted with ErrDriverClosed, and
+// closes the underlying transport.
+func (d *Driver) Close() error {
+	d.closeOnce.Do(func() { close(d.closing) })
+	<-d.done
+	return d.transport.Close()
+}
+
+func (d *Driver) run() {
+	defer close(d.done)
+	listenTicker := time.NewTicker(idleListenInterval)
+	defer listenTicker.Stop()
+	for {
+		select {
+		case req := <-d.requests:
+			d.handle(req)
+		case <-d.closing:
+			d.drain()
+			return
+		case <-listenTicker.C:
+			d.listenForEvent()
+		}
+	}
+}
+
+// listenForEvent listens briefly for an unsolicited backward frame while
+// the driver is otherwise idle (e.g. a slave-initiated event), publishing
+// anything it receives to Subscribe channels. It never blocks longer than
+// idleListenInterval, so a newly queued Send is picked up promptly.
+func (d *Driver) listenForEvent() {
+	ctx, cancel := context.WithTimeout(context.Background(), idleListenInterval)
+	defer cancel()
+	frame, ok, err := d.transport.ReceiveBackward(ctx, idleListenInterval)
+	if err != nil || !ok {
+		return
+	}
+	d.publish(Response{Frame: frame, Ok: true})
+}
+
+// drain answers any request already queued in d.requests with
+// ErrDriverClosed so no caller of Send is left waiting forever.
+func (d *Driver) drain() {
+	for {
+		select {
+		case req := <-d.requests:
+			req.reply <- Response{Err: ErrDriverClosed}
+		default:
+			return
+		}
+	}
+}
+
+func (d *Driver) handle(req request) {
+	ctx := req.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var resp Response
+	for attempt := 0; ; attempt++ {
+		if err := d.transport.SendForward(ctx, req.frame); err != nil {
+			resp = Response{Err: err}
+			break
+		}
+
+		frame, ok, err := d.transport.ReceiveBackward(ctx, d.timing.ForwardToBackward)
+		var collision *daliframe.CollisionError
+		if errors.As(err, &collision) && attempt < maxCollisionRetries {
+			continue
+		}
+		resp = Response{Frame: frame, Ok: ok, Err: err}
+		break
+	}
+
+	d.settle(req.frame, resp)
+	req.reply <- resp
+}
+
+// settle sleeps the gap req.frame's classification requires before the
+// driver accepts the next request, picking the delay from timing instead
+// of a blanket 10 ms: a configuration command's first send only needs the
+// "send twice" minimum before its confirming repeat (§11.2), but once
+// that repeat arrives the config change itself needs the full
+// SettleAfterConfig before anything that relies on it (e.g. a query, or
+// the next command in a commissioning sequence) can trust it's taken
+// effect. A query that got an answer gets the backward-frame recovery
+// gap, and everything else (no backward frame expected) gets the plain
+// forward-to-forward gap.
+func (d *Driver) settle(frame daliframe.ForwardFrame, resp Response) {
+	switch {
+	case isConfigFrame(frame):
+		if d.hasPendingConfig && d.pendingConfigFrame == frame {
+			d.hasPendingConfig = false
+			time.Sleep(d.timing.SettleAfterConfig)
+			return
+		}
+		d.pendingConfigFrame = frame
+		d.hasPendingConfig = true
+		time.Sleep(d.timing.TwiceInterval)
+	case isQueryFrame(frame) && resp.Ok:
+		time.Sleep(d.timing.BackwardToForward)
+	default:
+		time.Sleep(d.timing.ForwardToForward)
+	}
+}
+
+// isConfigFrame reports whether frame is a configuration command whose
+// effect needs to settle before it can be relied on (IEC 62386-102
+// §11.2/§11.3 DTR writes, scene/group/fade storage and addressing).
+func isConfigFrame(frame daliframe.ForwardFrame) bool {
+	switch frame.Address {
+	case initialiseAddr, randomiseAddr, programShortAddrCmd:
+		return true
+	}
+	return frame.Command >= 0x20 && frame.Command <= 0x81
+}
+
+// isQueryFrame reports whether frame is a command a slave may answer
+// with a backward frame: the COMPARE special command, the 0x90-0xCF
+// addressed query range, and READ MEMORY LOCATION (0xE0).
+func isQueryFrame(frame daliframe.ForwardFrame) bool {
+	if frame.Address == compareAddr {
+		return true
+	}
+	if frame.Command == 0xE0 {
+		return true
+	}
+	return frame.Command >= 0x90 && frame.Command <= 0xCF
+}
+
+// publish fans an unsolicited backward frame out to subscribers, dropping
+// it for any subscriber whose channel is full.
+func (d *Driver) publish(resp Response) {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+	for _, sub := range d.subs {
+		select {
+		case sub <- resp:
+		default:
+		}
+	}
+}
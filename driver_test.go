@@ -0,0 +1,121 @@
+package dali
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+// fakeTransport is a Transport test double whose ReceiveBackward behavior
+// is driven by a queue of canned responders, one consumed per
+// ReceiveBackward call (so a retried SendForward can still be matched
+// with its own responder).
+type fakeTransport struct {
+	sent      []daliframe.ForwardFrame
+	responses []func() (daliframe.BackwardFrame, bool, error)
+	closed    bool
+}
+
+func (f *fakeTransport) SendForward(ctx context.Context, frame daliframe.ForwardFrame) error {
+	f.sent = append(f.sent, frame)
+	return nil
+}
+
+func (f *fakeTransport) ReceiveBackward(ctx context.Context, timeout time.Duration) (daliframe.BackwardFrame, bool, error) {
+	if len(f.responses) == 0 {
+		return daliframe.BackwardFrame{}, false, nil
+	}
+	next := f.responses[0]
+	f.responses = f.responses[1:]
+	return next()
+}
+
+func (f *fakeTransport) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestDriverSendCorrelatesResponse(t *testing.T) {
+	transport := &fakeTransport{
+		responses: []func() (daliframe.BackwardFrame, bool, error){
+			func() (daliframe.BackwardFrame, bool, error) {
+				return daliframe.BackwardFrame{Data: 0xFE}, true, nil
+			},
+		},
+	}
+	d := NewDriver(transport)
+	defer d.Close()
+
+	reply, err := d.Send(context.Background(), daliframe.ForwardFrame{Address: 0x01, Command: QUERY_STATUS})
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Err != nil {
+			t.Fatalf("response error: %v", resp.Err)
+		}
+		if !resp.Ok || resp.Frame.Data != 0xFE {
+			t.Fatalf("got %+v, want Ok=true Data=0xFE", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for response")
+	}
+}
+
+func TestDriverRetriesOnCollision(t *testing.T) {
+	collide := func() (daliframe.BackwardFrame, bool, error) {
+		return daliframe.BackwardFrame{}, false, &daliframe.CollisionError{}
+	}
+	succeed := func() (daliframe.BackwardFrame, bool, error) {
+		return daliframe.BackwardFrame{Data: 0x01}, true, nil
+	}
+	// One collision per retry, then a clean reply once retries run out.
+	transport := &fakeTransport{
+		responses: []func() (daliframe.BackwardFrame, bool, error){collide, collide, succeed},
+	}
+
+	d := NewDriver(transport)
+	defer d.Close()
+
+	reply, err := d.Send(context.Background(), daliframe.ForwardFrame{Address: 0x01, Command: ON_C})
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Err != nil {
+			t.Fatalf("response error: %v", resp.Err)
+		}
+		if !resp.Ok || resp.Frame.Data != 0x01 {
+			t.Fatalf("got %+v, want the post-retry success", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for response")
+	}
+
+	if len(transport.sent) != maxCollisionRetries+1 {
+		t.Fatalf("got %d SendForward calls, want %d", len(transport.sent), maxCollisionRetries+1)
+	}
+}
+
+func TestDriverCloseClosesTransportAndRejectsSend(t *testing.T) {
+	transport := &fakeTransport{}
+	d := NewDriver(transport)
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if !transport.closed {
+		t.Fatal("Close() did not close the underlying transport")
+	}
+
+	if _, err := d.Send(context.Background(), daliframe.ForwardFrame{}); !errors.Is(err, ErrDriverClosed) {
+		t.Fatalf("Send() after Close() error = %v, want ErrDriverClosed", err)
+	}
+}
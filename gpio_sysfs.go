@@ -0,0 +1,76 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : A Linux /sys/class/gpio backed OutputPin/InputPin pair,
+ *    					 so GPIOTransport can run on any board exposing the sysfs
+ *	 						 GPIO interface without pulling in a hardware-specific
+ *							 dependency.
+ *
+ */
+
+package dali
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const gpioSysfsPath = "/sys/class/gpio"
+
+// SysfsPin is a /sys/class/gpio GPIO line exported for either direction.
+type SysfsPin struct {
+	number int
+	value  *os.File
+}
+
+// ExportSysfsPin exports GPIO line number and configures it as "in" or
+// "out", ready to be used as an OutputPin or InputPin.
+func ExportSysfsPin(number int, direction string) (*SysfsPin, error) {
+	exportPath := gpioSysfsPath + "/export"
+	if err := os.WriteFile(exportPath, []byte(strconv.Itoa(number)), 0644); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("dali: export gpio%d: %w", number, err)
+	}
+
+	base := fmt.Sprintf("%s/gpio%d", gpioSysfsPath, number)
+	if err := os.WriteFile(base+"/direction", []byte(direction), 0644); err != nil {
+		return nil, fmt.Errorf("dali: set gpio%d direction %q: %w", number, direction, err)
+	}
+
+	value, err := os.OpenFile(base+"/value", os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("dali: open gpio%d value: %w", number, err)
+	}
+
+	return &SysfsPin{number: number, value: value}, nil
+}
+
+// Set implements OutputPin.
+func (p *SysfsPin) Set(high bool) error {
+	level := "0"
+	if high {
+		level = "1"
+	}
+	if _, err := p.value.WriteAt([]byte(level), 0); err != nil {
+		return fmt.Errorf("dali: write gpio%d: %w", p.number, err)
+	}
+	return nil
+}
+
+// Read implements InputPin.
+func (p *SysfsPin) Read() (bool, error) {
+	buf := make([]byte, 1)
+	if _, err := p.value.ReadAt(buf, 0); err != nil {
+		return false, fmt.Errorf("dali: read gpio%d: %w", p.number, err)
+	}
+	return buf[0] == '1', nil
+}
+
+// Unexport releases the GPIO line back to the kernel.
+func (p *SysfsPin) Unexport() error {
+	if err := p.value.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(gpioSysfsPath+"/unexport", []byte(strconv.Itoa(p.number)), 0644)
+}
@@ -0,0 +1,392 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : GPIOTransport drives a DALI bus by bit-banging a single
+ *    					 output pin and recovering backward frames by sampling a
+ *	 						 single input pin. DALI slaves only loosely track the
+ *							 master's timing, so the receive side runs an edge-resync
+ * 							 clock/data recovery (CDR) loop: each run of same-level
+ *							 ticks is turned into output half-bits only once it ends,
+ *							 rounded to the nearest half-bit count, instead of free-
+ *							 running on a fixed grid and slipping bits.
+ *
+ */
+
+package dali
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+// OutputPin is the minimal digital output a GPIOTransport needs to drive
+// the DALI line.
+type OutputPin interface {
+	Set(high bool) error
+}
+
+// InputPin is the minimal digital input a GPIOTransport needs to sample
+// the DALI line.
+type InputPin interface {
+	Read() (bool, error)
+}
+
+// Clock schedules a recurring callback every interval, abstracting
+// time.Ticker so tests can drive GPIOTransport's state machine tick by
+// tick instead of depending on wall-clock half-bit timing.
+type Clock interface {
+	// Tick starts invoking fn every interval and returns a function that
+	// stops it.
+	Tick(interval time.Duration, fn func()) (stop func())
+}
+
+// realClock schedules ticks with a standard time.Ticker.
+type realClock struct{}
+
+func (realClock) Tick(interval time.Duration, fn func()) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// halfBitDuration is one DALI half-bit period at the standard 1200 Bd
+// line rate (833 µs per bit, so 416.67 µs per half-bit).
+const halfBitDuration = 416670 * time.Nanosecond
+
+// subSamples is how many times GPIOTransport samples/drives the line
+// within a single half-bit. 8 gives the run-length rounding in rxFlush
+// room to absorb a slave running a few ticks fast or slow, comfortably
+// above the ~4x oversampling IEC 62386 timing requires. The sampling
+// point is not snapped to a fixed index within the half-bit window.
+const subSamples = 8
+
+type gpioState int
+
+const (
+	gpioIdle gpioState = iota
+	gpioTxStart
+	gpioTxLeft
+	gpioTxRight
+	gpioTxStop
+	gpioRxLeft
+	gpioRxRight
+)
+
+// GPIOTransport is a Transport that bit-bangs Manchester-encoded DALI
+// frames over a single output pin and recovers backward frames from a
+// single input pin, instead of relying on a UART.
+type GPIOTransport struct {
+	out   OutputPin
+	in    InputPin
+	clock Clock
+
+	mu         sync.Mutex
+	state      gpioState
+	stopTicker func()
+
+	txSamples []byte
+	txPos     int
+	txDone    chan error
+
+	rxSamples  []byte
+	rxRun      int
+	rxStarted  bool
+	lastSample bool
+	rxResult   chan rxOutcome
+}
+
+type rxOutcome struct {
+	frame daliframe.BackwardFrame
+	ok    bool
+	err   error
+}
+
+// NewGPIOTransport builds a GPIOTransport driving out and sampling in. A
+// nil clock uses a real time.Ticker; tests pass a fake Clock to drive the
+// state machine deterministically.
+func NewGPIOTransport(out OutputPin, in InputPin, clock Clock) *GPIOTransport {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &GPIOTransport{out: out, in: in, clock: clock, state: gpioIdle}
+}
+
+func (t *GPIOTransport) SendForward(ctx context.Context, frame daliframe.ForwardFrame) error {
+	t.mu.Lock()
+	if t.state != gpioIdle {
+		t.mu.Unlock()
+		return fmt.Errorf("dali: GPIOTransport busy")
+	}
+	t.txSamples = frame.Encode(subSamples)
+	t.txPos = 0
+	t.txDone = make(chan error, 1)
+	t.state = gpioTxStart
+	t.stopTicker = t.clock.Tick(halfBitDuration/subSamples, t.txTick)
+	t.mu.Unlock()
+
+	select {
+	case err := <-t.txDone:
+		return err
+	case <-ctx.Done():
+		t.mu.Lock()
+		stop := t.stopTicker
+		t.state = gpioIdle
+		t.mu.Unlock()
+		stop()
+		return ctx.Err()
+	}
+}
+
+// txHalfBitState reports which leg of the frame half-bit at index i
+// (0-based) the transport is currently driving, so callers can observe
+// IDLE/TX_START/TX_LEFT/TX_RIGHT/TX_STOP transitions even though the
+// sample buffer itself was already Manchester-encoded by daliframe.
+func txHalfBitState(i, total int) gpioState {
+	switch {
+	case i < 2:
+		return gpioTxStart
+	case i >= total-4:
+		return gpioTxStop
+	case (i-2)%2 == 0:
+		return gpioTxLeft
+	default:
+		return gpioTxRight
+	}
+}
+
+// txTick drives one output sample and runs on every clock tick while
+// transmitting.
+func (t *GPIOTransport) txTick() {
+	t.mu.Lock()
+	if t.txPos >= len(t.txSamples) {
+		t.state = gpioIdle
+		stop := t.stopTicker
+		done := t.txDone
+		t.mu.Unlock()
+		stop()
+		done <- nil
+		return
+	}
+	t.state = txHalfBitState(t.txPos/subSamples, len(t.txSamples)/subSamples)
+	level := t.txSamples[t.txPos] != 0
+	t.txPos++
+	finished := t.txPos >= len(t.txSamples)
+	t.mu.Unlock()
+
+	if err := t.out.Set(level); err != nil {
+		t.mu.Lock()
+		t.state = gpioIdle
+		stop := t.stopTicker
+		done := t.txDone
+		t.mu.Unlock()
+		stop()
+		done <- err
+		return
+	}
+
+	if finished {
+		t.mu.Lock()
+		t.state = gpioIdle
+		stop := t.stopTicker
+		done := t.txDone
+		t.mu.Unlock()
+		stop()
+		done <- nil
+	}
+}
+
+func (t *GPIOTransport) ReceiveBackward(ctx context.Context, timeout time.Duration) (daliframe.BackwardFrame, bool, error) {
+	t.mu.Lock()
+	if t.state != gpioIdle {
+		t.mu.Unlock()
+		return daliframe.BackwardFrame{}, false, fmt.Errorf("dali: GPIOTransport busy")
+	}
+	t.rxSamples = make([]byte, 0, daliframe.BackwardHalfBits*subSamples)
+	t.rxRun = 0
+	t.rxStarted = false
+	t.lastSample = true // line idles high; only matters once rxStarted
+	t.rxResult = make(chan rxOutcome, 1)
+	t.state = gpioRxLeft
+	t.mu.Unlock()
+
+	stop := t.clock.Tick(halfBitDuration/subSamples, t.rxTick)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case out := <-t.rxResult:
+		stop()
+		return out.frame, out.ok, out.err
+	case <-timer.C:
+		stop()
+		t.mu.Lock()
+		t.state = gpioIdle
+		t.mu.Unlock()
+		return daliframe.BackwardFrame{}, false, nil
+	case <-ctx.Done():
+		stop()
+		t.mu.Lock()
+		t.state = gpioIdle
+		t.mu.Unlock()
+		return daliframe.BackwardFrame{}, false, ctx.Err()
+	}
+}
+
+// rxRunFlushThreshold bounds how long rxTick lets a same-level run grow
+// before eagerly committing whole half-bits out of it (needed so a run
+// that never ends on an edge, e.g. the trailing idle-high stop bits,
+// still gets flushed). It's a full extra half-bit above subSamples, not
+// just a fraction of one, so a slave running up to a half-bit slow never
+// has its still-in-progress half-bit flushed early only to have more
+// same-level ticks arrive right after - which would leave a fixed
+// leftover remainder that then double-counts as a second half-bit the
+// moment the run actually ends.
+const rxRunFlushThreshold = 2 * subSamples
+
+// rxFlush commits ticks consecutive same-level ticks (level) as whole
+// half-bits: ticks is rounded to the nearest multiple of subSamples rather
+// than assumed to be exactly one, so a slave whose clock runs fast or
+// slow relative to ours - making its half-bits consistently a few ticks
+// shorter or longer than we expect - still produces the right number of
+// output half-bits instead of drifting the sample grid out of phase over
+// the length of the frame. ticks can round down to zero half-bits (a
+// leftover remainder too short to be a half-bit in its own right, e.g.
+// just after an eager flush below).
+func (t *GPIOTransport) rxFlush(level bool, ticks int) {
+	halfBits := (ticks + subSamples/2) / subSamples
+	var sample byte
+	if level {
+		sample = 1
+	}
+	for i := 0; i < halfBits*subSamples; i++ {
+		t.rxSamples = append(t.rxSamples, sample)
+	}
+}
+
+// rxTick samples the input pin and applies edge-resync CDR: ticks are
+// accumulated per contiguous run of the same level rather than eagerly
+// appended sample-by-sample, and each run is only turned into output
+// half-bits (via rxFlush) once it ends - either because the line changed
+// level (the common case) or because the run has gone on long enough
+// (rxRunFlushThreshold) that whole half-bits out of it are certainly
+// done, needed for a run with no further edge to end it, e.g. the
+// trailing stop bits. A loosely synced slave's half-bits land a little
+// early or late on our sample grid; resolving each run's length when it
+// ends, instead of trusting a free-running counter, keeps later
+// half-bits' centre sample on stable signal instead of drifting further
+// out of phase over the frame.
+func (t *GPIOTransport) rxTick() {
+	level, err := t.in.Read()
+
+	t.mu.Lock()
+	if t.state == gpioIdle {
+		t.mu.Unlock()
+		return
+	}
+	if err != nil {
+		t.state = gpioIdle
+		result := t.rxResult
+		t.mu.Unlock()
+		result <- rxOutcome{err: err}
+		return
+	}
+
+	switch {
+	case !t.rxStarted:
+		// The very first sample starts the frame's own first run; there is
+		// no preceding idle run to flush (the line idling high before this
+		// point isn't part of the encoded frame).
+		t.rxStarted = true
+		t.lastSample = level
+		t.rxRun = 1
+	case level == t.lastSample:
+		t.rxRun++
+		committed := len(t.rxSamples) / subSamples
+		pending := (t.rxRun + subSamples/2) / subSamples
+		switch {
+		case t.rxRun >= subSamples && committed+pending >= daliframe.BackwardHalfBits:
+			// This run alone would already complete the frame: there is no
+			// following half-bit left to wait an edge for (the trailing
+			// stop bits never end in one), so resolve it now by rounding
+			// rather than stalling forever on ticks that will never come.
+			t.rxFlush(level, t.rxRun)
+			t.rxRun = 0
+		case t.rxRun >= rxRunFlushThreshold:
+			// The run has gone on long enough that whole half-bits of it
+			// are certainly done; commit exactly those (no rounding - we
+			// know precisely how many full subSamples-ticks groups have
+			// elapsed) and keep only the short remainder pending, in case
+			// the run continues further.
+			whole := t.rxRun / subSamples
+			t.rxFlush(level, whole*subSamples)
+			t.rxRun -= whole * subSamples
+		}
+	default:
+		// rxRun is 0 here if the previous run already flushed exactly at a
+		// half-bit boundary (the common, no-drift case): nothing pending to
+		// flush again for it.
+		if t.rxRun > 0 {
+			t.rxFlush(t.lastSample, t.rxRun)
+		}
+		t.lastSample = level
+		t.rxRun = 1
+	}
+
+	halfBitIndex := len(t.rxSamples) / subSamples
+	if halfBitIndex%2 == 0 {
+		t.state = gpioRxLeft
+	} else {
+		t.state = gpioRxRight
+	}
+
+	if len(t.rxSamples) < daliframe.BackwardHalfBits*subSamples {
+		t.mu.Unlock()
+		return
+	}
+
+	t.state = gpioIdle
+	// Rounding a run up to the nearest half-bit can overshoot the exact
+	// sample count decode expects by up to one half-bit; trim to it.
+	samples := t.rxSamples
+	if want := daliframe.BackwardHalfBits * subSamples; len(samples) > want {
+		samples = samples[:want]
+	}
+	result := t.rxResult
+	t.mu.Unlock()
+
+	frame, err := daliframe.DecodeBackwardFrame(samples, subSamples)
+	if err != nil {
+		result <- rxOutcome{err: err}
+		return
+	}
+	result <- rxOutcome{frame: frame, ok: true}
+}
+
+func (t *GPIOTransport) Close() error {
+	t.mu.Lock()
+	stop := t.stopTicker
+	t.state = gpioIdle
+	t.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package dali
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+// manualClock lets a test drive a GPIOTransport's state machine one
+// sub-sample at a time instead of waiting on wall-clock half-bit timing.
+// fn is set from the transport's own goroutine and read from the test
+// goroutine, so access goes through mu.
+type manualClock struct {
+	mu sync.Mutex
+	fn func()
+}
+
+func (c *manualClock) Tick(interval time.Duration, fn func()) (stop func()) {
+	c.mu.Lock()
+	c.fn = fn
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		c.fn = nil
+		c.mu.Unlock()
+	}
+}
+
+func (c *manualClock) ready() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fn != nil
+}
+
+func (c *manualClock) step() {
+	c.mu.Lock()
+	fn := c.fn
+	c.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// recordingPin is a fake OutputPin that records every level it is set to.
+type recordingPin struct {
+	levels []byte
+}
+
+func (p *recordingPin) Set(high bool) error {
+	if high {
+		p.levels = append(p.levels, 1)
+	} else {
+		p.levels = append(p.levels, 0)
+	}
+	return nil
+}
+
+// playbackPin is a fake InputPin that replays a fixed sequence of levels.
+type playbackPin struct {
+	levels []byte
+	pos    int
+}
+
+func (p *playbackPin) Read() (bool, error) {
+	if p.pos >= len(p.levels) {
+		return p.levels[len(p.levels)-1] != 0, nil
+	}
+	v := p.levels[p.pos] != 0
+	p.pos++
+	return v, nil
+}
+
+func TestGPIOTransportSendForward(t *testing.T) {
+	frame := daliframe.ForwardFrame{Address: 0b10100101, Command: 0xFF}
+	want := frame.Encode(subSamples)
+
+	out := &recordingPin{}
+	clock := &manualClock{}
+	tx := NewGPIOTransport(out, &playbackPin{levels: []byte{1}}, clock)
+
+	done := make(chan error, 1)
+	go func() { done <- tx.SendForward(context.Background(), frame) }()
+
+	for !clock.ready() {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < len(want); i++ {
+		clock.step()
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("SendForward() error: %v", err)
+	}
+	if len(out.levels) != len(want) {
+		t.Fatalf("got %d samples driven, want %d", len(out.levels), len(want))
+	}
+	for i := range want {
+		if out.levels[i] != want[i] {
+			t.Fatalf("sample %d = %d, want %d", i, out.levels[i], want[i])
+		}
+	}
+}
+
+func TestGPIOTransportReceiveBackward(t *testing.T) {
+	want := daliframe.BackwardFrame{Data: 0xA5}
+	frame, err := receiveBackwardSamples(t, want.Encode(subSamples))
+	if err != nil {
+		t.Fatalf("ReceiveBackward() error: %v", err)
+	}
+	if frame != want {
+		t.Fatalf("ReceiveBackward() = %+v, want %+v", frame, want)
+	}
+}
+
+// TestGPIOTransportReceiveBackwardDrifted feeds ReceiveBackward a frame
+// sampled at an oversample other than subSamples, simulating a slave
+// whose clock runs a bit fast or slow relative to ours: at subSamples=8,
+// 7 and 9 samples/half-bit are a realistic ±12.5% drift. Without the
+// edge-resync CDR in rxTick, decodeHalfBits would reject these outright
+// (wrong total sample count); ReceiveBackward must still recover the
+// frame by resolving each half-bit's length as its run of samples ends,
+// rather than trusting a free-running subSamples-wide grid.
+func TestGPIOTransportReceiveBackwardDrifted(t *testing.T) {
+	for _, oversample := range []int{7, 9} {
+		t.Run(fmt.Sprintf("oversample=%d", oversample), func(t *testing.T) {
+			want := daliframe.BackwardFrame{Data: 0x5A}
+			samples := want.Encode(oversample)
+
+			if _, err := daliframe.DecodeBackwardFrame(samples, subSamples); err == nil {
+				t.Fatalf("naive decode at subSamples=%d unexpectedly succeeded on oversample=%d samples", subSamples, oversample)
+			}
+
+			frame, err := receiveBackwardSamples(t, samples)
+			if err != nil {
+				t.Fatalf("ReceiveBackward() error: %v", err)
+			}
+			if frame != want {
+				t.Fatalf("ReceiveBackward() = %+v, want %+v", frame, want)
+			}
+		})
+	}
+}
+
+// receiveBackwardSamples drives a GPIOTransport's ReceiveBackward with a
+// manualClock, feeding exactly samples one tick at a time, and returns
+// the decoded frame.
+func receiveBackwardSamples(t *testing.T, samples []byte) (daliframe.BackwardFrame, error) {
+	t.Helper()
+
+	in := &playbackPin{levels: samples}
+	clock := &manualClock{}
+	rx := NewGPIOTransport(&recordingPin{}, in, clock)
+
+	type result struct {
+		frame daliframe.BackwardFrame
+		ok    bool
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		frame, ok, err := rx.ReceiveBackward(context.Background(), time.Second)
+		done <- result{frame, ok, err}
+	}()
+
+	for !clock.ready() {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < len(samples); i++ {
+		clock.step()
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return daliframe.BackwardFrame{}, r.err
+		}
+		if !r.ok {
+			t.Fatal("ReceiveBackward() ok = false, want true")
+		}
+		return r.frame, nil
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for ReceiveBackward")
+		return daliframe.BackwardFrame{}, nil
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Timing captures the IEC 62386-101 settling times between
+ *    					 frames. Every command used to sleep a blanket 10 ms, which
+ *	 						 is both too slow for the frames that only need 2.4 ms and
+ *							 too fast for the 100 ms a configuration command needs to
+ * 							 take effect.
+ *
+ */
+
+package dali
+
+import "time"
+
+// Timing names the settling delays IEC 62386-101 requires between
+// frames. All fields except ForwardToBackward are minimum gaps;
+// ForwardToBackward is the longest wait for a reply. DefaultTiming returns
+// the values the standard specifies.
+type Timing struct {
+	// ForwardToForward is the minimum gap after a forward frame no slave
+	// is expected to answer, before the next forward frame.
+	ForwardToForward time.Duration
+	// ForwardToBackward is how long a slave may take to start a backward
+	// frame reply after a forward frame; the driver waits up to this long
+	// before concluding no slave answered.
+	ForwardToBackward time.Duration
+	// BackwardToForward is the minimum gap after a backward frame reply,
+	// before the next forward frame.
+	BackwardToForward time.Duration
+	// TwiceInterval is the minimum gap between the two identical frames
+	// of a "send twice" configuration command.
+	TwiceInterval time.Duration
+	// SettleAfterConfig is the minimum time a configuration command (DTR
+	// writes, scene/group storage, addressing, ...) needs to take effect
+	// before the next command.
+	SettleAfterConfig time.Duration
+}
+
+// DefaultTiming returns the IEC 62386-101 minimum settling times and
+// backward frame reply window.
+func DefaultTiming() Timing {
+	return Timing{
+		ForwardToForward:  2400 * time.Microsecond,
+		ForwardToBackward: 22 * 833 * time.Microsecond,
+		BackwardToForward: 5500 * time.Microsecond,
+		TwiceInterval:     9170 * time.Microsecond,
+		SettleAfterConfig: 100 * time.Millisecond,
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ *
+ * author : Thomas Georgiadis
+ *
+ * Description : Transport abstracts the physical layer a Driver talks to.
+ *    					 SerialTransport keeps the original goburrow/serial based
+ *	 						 behaviour working; GPIOTransport (gpio_transport.go) bit-bangs
+ *							 the bus directly and is able to recover backward frames whose
+ * 							 timing only loosely tracks the master.
+ *
+ */
+
+package dali
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThomasGeor/dali/daliframe"
+)
+
+// Transport sends DALI forward frames and receives the backward frame (if
+// any) that follows, independent of whether the bus is driven over a UART
+// or bit-banged GPIO pins.
+type Transport interface {
+	// SendForward transmits a forward frame on the bus.
+	SendForward(ctx context.Context, frame daliframe.ForwardFrame) error
+	// ReceiveBackward waits up to timeout for a backward frame. It returns
+	// (BackwardFrame{}, false, nil) if the timeout elapses with no reply,
+	// which is the normal case for commands no slave answers.
+	ReceiveBackward(ctx context.Context, timeout time.Duration) (frame daliframe.BackwardFrame, ok bool, err error)
+	// Close releases the underlying port/pins.
+	Close() error
+}
+
+// SerialTransport is a Transport backed by a UART configured for the DALI
+// 1200 bps 8N2 framing, using the byte packing create_dali_frame already
+// produces.
+type SerialTransport struct {
+	port Port
+}
+
+// NewSerialTransport wraps an already-open serial Port (see
+// Create_Serial_Connection) as a Transport.
+func NewSerialTransport(port Port) *SerialTransport {
+	return &SerialTransport{port: port}
+}
+
+func (t *SerialTransport) SendForward(ctx context.Context, frame daliframe.ForwardFrame) error {
+	message := create_dali_frame(frame.Address, frame.Command)
+	_, err := t.port.Write(message)
+	return err
+}
+
+// ReceiveBackward ignores timeout and ctx: it blocks in a single Read on
+// the port, bounded only by the port's own read timeout, and returns any
+// Read error as is. Only a Read of zero bytes is reported as no reply.
+func (t *SerialTransport) ReceiveBackward(ctx context.Context, timeout time.Duration) (daliframe.BackwardFrame, bool, error) {
+	// 3 bytes to fit the 22 bit response message.
+	response := make([]byte, 3)
+	n, err := t.port.Read(response)
+	if err != nil {
+		return daliframe.BackwardFrame{}, false, err
+	}
+	if n == 0 {
+		return daliframe.BackwardFrame{}, false, nil
+	}
+	return daliframe.BackwardFrame{Data: response[1]}, true, nil
+}
+
+func (t *SerialTransport) Close() error {
+	return Close_serial_connection(t.port)
+}